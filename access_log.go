@@ -0,0 +1,123 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// defaultMaxBodyBytes bounds how much of a request/response body is
+// captured when CaptureRequestBody/CaptureResponseBody is on but
+// MaxBodyBytes is unset.
+const defaultMaxBodyBytes = 64 * 1024
+
+// teeReadCloser tees reads from an http.Request body into buf while still
+// letting the underlying body be closed normally.
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// boundedBuffer caps how many bytes are retained in buf, while still
+// reporting the true number of bytes written via Len(). This lets us
+// bound the memory used to capture a request body for logging without
+// losing the real transfer size for BytesIn.
+type boundedBuffer struct {
+	buf bytes.Buffer
+	max int
+	n   int
+}
+
+func (b *boundedBuffer) Write(p []byte) (int, error) {
+	b.n += len(p)
+
+	if room := b.max - b.buf.Len(); room > 0 {
+		if room > len(p) {
+			room = len(p)
+		}
+
+		b.buf.Write(p[:room])
+	}
+
+	return len(p), nil
+}
+
+func (b *boundedBuffer) String() string {
+	return b.buf.String()
+}
+
+func (b *boundedBuffer) Len() int {
+	return b.n
+}
+
+// bodyCapturingWriter wraps a middleware.WrapResponseWriter and mirrors
+// everything written through it into buf, up to max bytes.
+type bodyCapturingWriter struct {
+	middleware.WrapResponseWriter
+	buf *bytes.Buffer
+	max int
+}
+
+func (w *bodyCapturingWriter) Write(b []byte) (int, error) {
+	if room := w.max - w.buf.Len(); room > 0 {
+		if room > len(b) {
+			room = len(b)
+		}
+
+		w.buf.Write(b[:room])
+	}
+
+	return w.WrapResponseWriter.Write(b)
+}
+
+// isSkipped reports whether access logging should be skipped for r,
+// per cfg.SkipPaths / cfg.Skipper.
+func isSkipped(r *http.Request, cfg Config) bool {
+	if cfg.Skipper != nil && cfg.Skipper(r) {
+		return true
+	}
+
+	for _, path := range cfg.SkipPaths {
+		if r.URL.Path == path {
+			return true
+		}
+	}
+
+	return false
+}
+
+// redactedHeaders copies h into a plain map, replacing the value of any
+// header named in redact (case-insensitively) with "***".
+func redactedHeaders(h http.Header, redact []string) map[string]string {
+	if len(h) == 0 {
+		return nil
+	}
+
+	redacted := make(map[string]struct{}, len(redact))
+	for _, name := range redact {
+		redacted[strings.ToLower(name)] = struct{}{}
+	}
+
+	out := make(map[string]string, len(h))
+	for name, values := range h {
+		if _, ok := redacted[strings.ToLower(name)]; ok {
+			out[name] = "***"
+			continue
+		}
+
+		out[name] = strings.Join(values, ", ")
+	}
+
+	return out
+}
+
+func truncate(s string, max int) string {
+	if max <= 0 || len(s) <= max {
+		return s
+	}
+
+	return s[:max]
+}
@@ -1,30 +1,44 @@
 package logger
 
+import "fmt"
+
 // Entry is a log entry.
 // Entry contains all fields required by our structured logging.
 type Entry struct {
-	Args            string `json:"args"`
-	CauserID        string `json:"causer_id"`
-	CauserType      string `json:"causer_type"`
-	ContextAsString string `json:"context_as_string"`
-	DataId          string `json:"data_id"`
-	DataType        string `json:"data_type"`
-	Datetime        string `json:"datetime"`
-	Delta           int    `json:"delta"`
-	Env             string `json:"env"`
-	Impersonator    string `json:"impersonator"`
-	Level           string `json:"level"`
-	Message         string `json:"message"`
-	ProcessContext  string `json:"process_context"`
-	ProcessStart    string `json:"process_start"`
-	RemoteAddress   string `json:"remote_address"`
-	RequestMethod   string `json:"request_method"`
-	RequestQuery    string `json:"request_query"`
-	RequestURL      string `json:"request_url"`
-	Service         string `json:"service"`
-	StatusCode      int    `json:"status_code"`
-	TraceID         string `json:"trace_id"`
-	Type            string `json:"type"`
+	Args            string            `json:"args"`
+	BytesIn         int               `json:"bytes_in,omitempty"`
+	BytesOut        int               `json:"bytes_out,omitempty"`
+	CauserID        string            `json:"causer_id"`
+	CauserType      string            `json:"causer_type"`
+	ContextAsString string            `json:"context_as_string"`
+	DataId          string            `json:"data_id"`
+	DataType        string            `json:"data_type"`
+	Datetime        string            `json:"datetime"`
+	Delta           int               `json:"delta"`
+	Env             string            `json:"env"`
+	ErrorMessage    string            `json:"error_message,omitempty"`
+	Fields          map[string]any    `json:"fields,omitempty"`
+	GRPCCode        string            `json:"grpc_code,omitempty"`
+	GRPCMethod      string            `json:"grpc_method,omitempty"`
+	Impersonator    string            `json:"impersonator"`
+	Level           string            `json:"level"`
+	Message         string            `json:"message"`
+	ProcessContext  string            `json:"process_context"`
+	ProcessStart    string            `json:"process_start"`
+	RemoteAddress   string            `json:"remote_address"`
+	RequestBody     string            `json:"request_body,omitempty"`
+	RequestHeaders  map[string]string `json:"request_headers,omitempty"`
+	RequestMethod   string            `json:"request_method"`
+	RequestQuery    string            `json:"request_query"`
+	RequestURL      string            `json:"request_url"`
+	ResponseBody    string            `json:"response_body,omitempty"`
+	ResponseHeaders map[string]string `json:"response_headers,omitempty"`
+	Service         string            `json:"service"`
+	Severity        Severity          `json:"severity,omitempty"`
+	Stack           string            `json:"stack,omitempty"`
+	StatusCode      int               `json:"status_code"`
+	TraceID         string            `json:"trace_id"`
+	Type            string            `json:"type"`
 }
 
 // WithContext adds context to a log entry.
@@ -37,3 +51,63 @@ func (e *Entry) WithContext(context string) *Entry {
 
 	return e
 }
+
+// wellKnownFields maps keys passed to WithField/WithFields/Logger.With to
+// the flat top-level Entry field they've always populated, so existing
+// consumers of the JSON shape keep working. Anything not in this map is
+// serialized under the nested "fields" object instead.
+var wellKnownFields = map[string]func(*Entry, string){
+	"causer_id":    func(e *Entry, v string) { e.CauserID = v },
+	"causer_type":  func(e *Entry, v string) { e.CauserType = v },
+	"data_id":      func(e *Entry, v string) { e.DataId = v },
+	"data_type":    func(e *Entry, v string) { e.DataType = v },
+	"impersonator": func(e *Entry, v string) { e.Impersonator = v },
+	"trace_id":     func(e *Entry, v string) { e.TraceID = v },
+}
+
+// WithField adds a single key/value pair to the entry. Well-known keys
+// (causer_id, data_id, trace_id, etc.) continue to populate their flat
+// top-level JSON field; anything else is nested under "fields".
+func (e *Entry) WithField(key string, val any) *Entry {
+	applyField(e, key, val)
+	return e
+}
+
+// WithError records err on the entry and, if a stack hasn't already been
+// captured (e.g. by Config.CaptureStackAtLevel), captures one now so the
+// caller of WithError is the top frame.
+func (e *Entry) WithError(err error) *Entry {
+	if err == nil {
+		return e
+	}
+
+	e.ErrorMessage = err.Error()
+
+	if e.Stack == "" {
+		e.Stack = captureStack()
+	}
+
+	return e
+}
+
+// WithFields adds a set of key/value pairs to the entry. See WithField.
+func (e *Entry) WithFields(fields map[string]any) *Entry {
+	for key, val := range fields {
+		applyField(e, key, val)
+	}
+
+	return e
+}
+
+func applyField(e *Entry, key string, val any) {
+	if setFlat, ok := wellKnownFields[key]; ok {
+		setFlat(e, fmt.Sprint(val))
+		return
+	}
+
+	if e.Fields == nil {
+		e.Fields = map[string]any{}
+	}
+
+	e.Fields[key] = val
+}
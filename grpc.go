@@ -0,0 +1,154 @@
+package logger
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// traceIDFromIncomingContext pulls a trace/request ID out of gRPC
+// metadata, mirroring the x-trace-id header Middleware reads over HTTP.
+func traceIDFromIncomingContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	for _, key := range []string{"x-trace-id", "x-request-id"} {
+		if values := md.Get(key); len(values) > 0 && values[0] != "" {
+			return values[0]
+		}
+	}
+
+	return ""
+}
+
+// UnaryServerInterceptor creates a gRPC unary server interceptor that
+// gives each RPC its own Logger, stashed in the context passed to the
+// handler under the same key Middleware uses so FromContext works uniformly.
+func UnaryServerInterceptor(cfg Config) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		lgr := NewLogger(cfg).WithGRPCMethod(info.FullMethod)
+
+		if tid := traceIDFromIncomingContext(ctx); tid != "" {
+			lgr.traceID = tid
+		}
+
+		if p, ok := peer.FromContext(ctx); ok {
+			lgr.WithPeerAddress(p.Addr.String())
+		}
+
+		defer lgr.Flush()
+
+		ctx = context.WithValue(ctx, contextKeyRequest, lgr)
+
+		resp, err := handler(ctx, req)
+
+		code := status.Code(err).String()
+
+		lgr.DecorateEntries(func(e *Entry) *Entry {
+			e.GRPCCode = code
+			return e
+		})
+
+		lgr.Log(Info, info.FullMethod).GRPCCode = code
+
+		return resp, err
+	}
+}
+
+// serverStreamWithContext overrides grpc.ServerStream.Context so a
+// logger can be injected for the duration of a streaming RPC.
+type serverStreamWithContext struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStreamWithContext) Context() context.Context {
+	return s.ctx
+}
+
+// StreamServerInterceptor creates a gRPC stream server interceptor that
+// gives each RPC its own Logger, retrievable via FromContext(ss.Context()).
+func StreamServerInterceptor(cfg Config) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		lgr := NewLogger(cfg).WithGRPCMethod(info.FullMethod)
+
+		if tid := traceIDFromIncomingContext(ss.Context()); tid != "" {
+			lgr.traceID = tid
+		}
+
+		if p, ok := peer.FromContext(ss.Context()); ok {
+			lgr.WithPeerAddress(p.Addr.String())
+		}
+
+		defer lgr.Flush()
+
+		wrapped := &serverStreamWithContext{
+			ServerStream: ss,
+			ctx:          context.WithValue(ss.Context(), contextKeyRequest, lgr),
+		}
+
+		err := handler(srv, wrapped)
+
+		code := status.Code(err).String()
+
+		lgr.DecorateEntries(func(e *Entry) *Entry {
+			e.GRPCCode = code
+			return e
+		})
+
+		lgr.Log(Info, info.FullMethod).GRPCCode = code
+
+		return err
+	}
+}
+
+// UnaryClientInterceptor creates a gRPC unary client interceptor that
+// logs each outgoing call the same way UnaryServerInterceptor logs
+// incoming ones.
+func UnaryClientInterceptor(cfg Config) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		lgr := NewLogger(cfg).WithGRPCMethod(method)
+		defer lgr.Flush()
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+
+		code := status.Code(err).String()
+
+		lgr.DecorateEntries(func(e *Entry) *Entry {
+			e.GRPCCode = code
+			return e
+		})
+
+		lgr.Log(Info, method).GRPCCode = code
+
+		return err
+	}
+}
+
+// StreamClientInterceptor creates a gRPC stream client interceptor that
+// logs each outgoing streaming call the same way StreamServerInterceptor
+// logs incoming ones.
+func StreamClientInterceptor(cfg Config) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		lgr := NewLogger(cfg).WithGRPCMethod(method)
+		defer lgr.Flush()
+
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+
+		code := status.Code(err).String()
+
+		lgr.DecorateEntries(func(e *Entry) *Entry {
+			e.GRPCCode = code
+			return e
+		})
+
+		lgr.Log(Info, method).GRPCCode = code
+
+		return cs, err
+	}
+}
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -8,6 +9,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"runtime/debug"
 	"strings"
 	"time"
 
@@ -23,6 +25,7 @@ const (
 	Info
 	Warn
 	Error
+	Fatal
 )
 
 // contextKey is used to register the logger into context.
@@ -46,22 +49,71 @@ func (s level) String() string {
 		return "warn"
 	case Error:
 		return "error"
+	case Fatal:
+		return "fatal"
 	}
 
 	return "unknown"
 }
 
+// LevelPtr returns a pointer to lvl, for Config fields that need to tell
+// an explicit level apart from "unset", e.g. CaptureStackAtLevel.
+func LevelPtr(lvl level) *level {
+	return &lvl
+}
+
+// parseLevel parses the string form of a level, as produced by
+// level.String(). It's used to apply the LEVEL/LOG_LEVEL env var override.
+func parseLevel(s string) (level, bool) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return Debug, true
+	case "info":
+		return Info, true
+	case "warn":
+		return Warn, true
+	case "error":
+		return Error, true
+	case "fatal":
+		return Fatal, true
+	}
+
+	return Debug, false
+}
+
 // Logger is main entity in this package.
 // Logger handles writing of logs.
 // In an HTTP context, a new logger is created per request.
 type Logger struct {
-	Entries   []*Entry
-	env       string
-	request   *http.Request
-	startTime time.Time
-	traceID   string
-	writer    io.Writer
-	service   string
+	Entries []*Entry
+	// entriesRef, once set, is the true home of this logger's buffered
+	// entries, shared with every ancestor/descendant produced by With so
+	// that a single Flush -- the one Middleware/the gRPC interceptors
+	// already defer on the logger they put in context -- sees entries
+	// logged through any of them. Entries itself is left as a normal
+	// exported field for direct construction (e.g. in tests); see
+	// ensureEntriesRef.
+	entriesRef *[]*Entry
+	env        string
+	request    *http.Request
+	startTime  time.Time
+	traceID    string
+	writer     io.Writer
+	service    string
+	sinks      *Sinks
+	fields     map[string]any
+
+	// processContext and peerAddress back non-HTTP entries, e.g. gRPC,
+	// where there's no *http.Request to derive RemoteAddress/ProcessContext
+	// from. grpcMethod is applied to every entry produced by this logger.
+	processContext string
+	peerAddress    string
+	grpcMethod     string
+
+	minLevel            level
+	sampler             func(*Entry) bool
+	exitFunc            func(int)
+	captureStackAtLevel level
 }
 
 // Config is used to configure the logger.
@@ -70,6 +122,58 @@ type Config struct {
 	Writer  io.Writer
 	Env     string
 	Service string
+
+	// Sinks delivers entries to remote destinations (Google Cloud
+	// Logging, Datadog, an OTLP endpoint, ...) in addition to Writer.
+	// Build it once with NewSinks at application startup and share it
+	// across every Logger (Middleware and the gRPC interceptors create
+	// one Logger per request/RPC) -- it owns long-lived worker
+	// goroutines that outlive any single Logger.
+	Sinks *Sinks
+
+	// AccessLog makes Middleware emit a dedicated "http" entry per
+	// request with method, path, query, remote addr, status code,
+	// bytes in/out, and duration.
+	AccessLog bool
+
+	// CaptureRequestBody and CaptureResponseBody additionally capture
+	// the request/response body on the access-log entry, up to
+	// MaxBodyBytes. Both require AccessLog.
+	CaptureRequestBody  bool
+	CaptureResponseBody bool
+
+	// MaxBodyBytes bounds how much of a captured body is kept. Defaults
+	// to 64KB if unset.
+	MaxBodyBytes int
+
+	// RedactHeaders lists header names (case-insensitive) whose value is
+	// replaced with "***" in the access-log entry, e.g. "Authorization".
+	RedactHeaders []string
+
+	// SkipPaths and Skipper exclude matching requests from the access
+	// log, e.g. health checks.
+	SkipPaths []string
+	Skipper   func(*http.Request) bool
+
+	// MinLevel drops entries below this level in Log(), before the rest
+	// of the entry is built. Overridden by the LEVEL or LOG_LEVEL env
+	// var if either is set to a recognized level name.
+	MinLevel level
+
+	// Sampler, if set, is consulted for every entry that passes
+	// MinLevel; returning false drops the entry. See NewRateSampler.
+	Sampler func(*Entry) bool
+
+	// ExitFunc is called by Fatal instead of os.Exit, so tests can
+	// assert Fatal was invoked without terminating the process.
+	ExitFunc func(int)
+
+	// CaptureStackAtLevel captures a stack trace on every entry logged
+	// at this level or higher. Defaults to Error if nil; since Debug is
+	// level's zero value, a plain level field couldn't tell "unset" apart
+	// from an explicit Debug, so this takes a *level -- use LevelPtr to
+	// build one, e.g. LevelPtr(Debug) to capture a stack on every entry.
+	CaptureStackAtLevel *level
 }
 
 // Debug messages are used to debug the application.
@@ -96,13 +200,101 @@ func (l *Logger) Error(msg string) *Entry {
 	return l.Log(Error, msg)
 }
 
+// Fatal logs msg at the Fatal level, synchronously flushes (including any
+// async remote sinks), and then exits the process via l.exitFunc (os.Exit
+// by default).
+func (l *Logger) Fatal(msg string) *Entry {
+	entry := l.Log(Fatal, msg)
+
+	l.FlushSync()
+
+	exitFunc := l.exitFunc
+	if exitFunc == nil {
+		exitFunc = os.Exit
+	}
+
+	exitFunc(1)
+
+	return entry
+}
+
+// ensureEntriesRef returns the pointer to this logger's entry buffer,
+// lazily pointing it at its own Entries field the first time it's
+// needed. A child produced by With shares its parent's ref (set before
+// the struct copy in With), so every descendant appends to -- and a
+// Flush/DecorateEntries on any one of them sees -- the same buffer.
+func (l *Logger) ensureEntriesRef() *[]*Entry {
+	if l.entriesRef == nil {
+		l.entriesRef = &l.Entries
+	}
+
+	return l.entriesRef
+}
+
+// entries returns the current, shared entry buffer.
+func (l *Logger) entries() []*Entry {
+	return *l.ensureEntriesRef()
+}
+
+// setEntries replaces the shared entry buffer and mirrors it onto this
+// logger's own Entries field for direct inspection.
+func (l *Logger) setEntries(entries []*Entry) {
+	*l.ensureEntriesRef() = entries
+	l.Entries = entries
+}
+
 func (l *Logger) Log(lvl level, msg string) *Entry {
+	if lvl < l.minLevel {
+		return &Entry{Level: lvl.String(), Message: msg}
+	}
+
 	entry := l.buildEntry(lvl, msg)
-	l.Entries = append(l.Entries, entry)
+
+	if lvl >= l.captureStackAtLevel {
+		entry.Stack = captureStack()
+	}
+
+	if l.sampler != nil && !l.sampler(entry) {
+		return entry
+	}
+
+	l.setEntries(append(l.entries(), entry))
 
 	return entry
 }
 
+// With returns a child logger that inherits all of this logger's fields
+// plus the key/value pairs given here, e.g. logger.With("user_id", id).
+// kv is a flat list of alternating string keys and values; an odd
+// trailing key is dropped. Use it to bind fields once per request and
+// have every subsequent Info/Warn/Error include them, instead of calling
+// DecorateEntries after the fact. The child shares the parent's entry
+// buffer, so entries logged through the child are still picked up by a
+// Flush on the parent (e.g. the one Middleware/the gRPC interceptors
+// defer on the logger they put in context).
+func (l *Logger) With(kv ...any) *Logger {
+	ref := l.ensureEntriesRef()
+
+	child := *l
+	child.entriesRef = ref
+	child.fields = make(map[string]any, len(l.fields)+len(kv)/2)
+
+	for key, val := range l.fields {
+		child.fields[key] = val
+	}
+
+	for i := 0; i+1 < len(kv); i += 2 {
+		key, ok := kv[i].(string)
+		if !ok {
+			continue
+		}
+
+		child.fields[key] = kv[i+1]
+	}
+
+	return &child
+}
+
 // WithRequest adds a request to the logger.
 // It also sets the trace ID if one exists.
 func (l *Logger) WithRequest(request *http.Request) *Logger {
@@ -115,13 +307,36 @@ func (l *Logger) WithRequest(request *http.Request) *Logger {
 	return l
 }
 
+// WithGRPCMethod adds a gRPC method to the logger. It also marks the
+// logger's entries as having a "grpc" process context rather than the
+// default "request".
+func (l *Logger) WithGRPCMethod(method string) *Logger {
+	l.grpcMethod = method
+	l.processContext = "grpc"
+
+	return l
+}
+
+// WithPeerAddress sets the remote address for entries that don't have an
+// *http.Request to derive it from, e.g. gRPC.
+func (l *Logger) WithPeerAddress(addr string) *Logger {
+	l.peerAddress = addr
+
+	return l
+}
+
 // buildEntry creates an Entry with all possible values.
 func (l *Logger) buildEntry(lvl level, msg string) *Entry {
 	startTime := l.startTime.Format(time.RFC3339)
 	now := time.Now().Format(time.RFC3339)
 	delta := time.Since(l.startTime)
 
-	remoteAddress := ""
+	processContext := "request"
+	if l.processContext != "" {
+		processContext = l.processContext
+	}
+
+	remoteAddress := l.peerAddress
 	requestMethod := ""
 	requestQuery := ""
 	requestURL := ""
@@ -133,54 +348,88 @@ func (l *Logger) buildEntry(lvl level, msg string) *Entry {
 		requestURL = l.request.Host + l.request.URL.Path
 	}
 
-	return &Entry{
+	entry := &Entry{
 		Args:           strings.Join(os.Args, " "),
 		Datetime:       now,
 		Delta:          int(delta.Milliseconds()),
 		Env:            l.env,
+		GRPCMethod:     l.grpcMethod,
 		Level:          lvl.String(),
 		Message:        msg,
-		ProcessContext: "request",
+		ProcessContext: processContext,
 		ProcessStart:   startTime,
 		RemoteAddress:  remoteAddress,
 		RequestMethod:  requestMethod,
 		RequestQuery:   requestQuery,
 		RequestURL:     requestURL,
 		Service:        l.service,
+		Severity:       severityFor(lvl),
 		TraceID:        l.traceID,
 		Type:           "general",
 	}
+
+	for key, val := range l.fields {
+		applyField(entry, key, val)
+	}
+
+	return entry
 }
 
 // DecorateEntries is used to modify existing entries.
 // It should be called after all log entries are created because it does not apply to future entries.
 func (l *Logger) DecorateEntries(decorators ...func(*Entry) *Entry) {
-	entries := l.Entries
-	for i := range l.Entries {
+	entries := l.entries()
+	for i := range entries {
 		for _, decorator := range decorators {
 			entries[i] = decorator(entries[i])
 		}
 	}
 
-	l.Entries = entries
+	l.setEntries(entries)
 }
 
-// Flush writes all buffered log entries.
-// The buffer is then flushed.
+// Flush writes all buffered log entries to the writer and hands them to
+// any configured sinks for asynchronous delivery. The buffer is then
+// cleared.
 func (l *Logger) Flush() {
-	if l.writer == nil {
-		l.Entries = []*Entry{}
-		return
-	}
+	l.flush(false)
+}
+
+// FlushSync behaves like Flush, but blocks until every configured sink
+// has actually sent this logger's entries, rather than only enqueuing
+// them for its background worker. Fatal uses this so a log line (and
+// anything else still queued) survives the os.Exit that follows it.
+func (l *Logger) FlushSync() {
+	l.flush(true)
+}
 
-	for _, e := range l.Entries {
-		data, err := json.Marshal(e)
-		if err == nil {
-			fmt.Fprintln(l.writer, string(data))
+func (l *Logger) flush(sync bool) {
+	entries := l.entries()
+
+	if l.writer != nil {
+		for _, e := range entries {
+			data, err := json.Marshal(e)
+			if err == nil {
+				fmt.Fprintln(l.writer, string(data))
+			}
 		}
 	}
 
-	l.Entries = []*Entry{}
+	if sync {
+		l.sinks.enqueueSync(entries)
+	} else {
+		l.sinks.enqueue(entries)
+	}
+
+	l.setEntries([]*Entry{})
+}
+
+// Close flushes any remaining entries. It does not shut down Config.Sinks
+// -- that pool is shared across every Logger created from the same
+// Config, so only the application that built it (via NewSinks) should
+// close it, once, during shutdown.
+func (l *Logger) Close() {
+	l.Flush()
 }
 
 func isJSON(str string) bool {
@@ -193,34 +442,122 @@ func isJSON(str string) bool {
 func NewLogger(cfg Config) *Logger {
 	traceID := uuid.New().String()
 
+	minLevel := cfg.MinLevel
+	for _, name := range []string{"LEVEL", "LOG_LEVEL"} {
+		if parsed, ok := parseLevel(os.Getenv(name)); ok {
+			minLevel = parsed
+			break
+		}
+	}
+
+	captureStackAtLevel := Error
+	if cfg.CaptureStackAtLevel != nil {
+		captureStackAtLevel = *cfg.CaptureStackAtLevel
+	}
+
 	return &Logger{
-		Entries:   []*Entry{},
-		env:       cfg.Env,
-		service:   cfg.Service,
-		startTime: time.Now(),
-		traceID:   traceID,
-		writer:    cfg.Writer,
+		Entries:             []*Entry{},
+		env:                 cfg.Env,
+		service:             cfg.Service,
+		startTime:           time.Now(),
+		traceID:             traceID,
+		writer:              cfg.Writer,
+		sinks:               cfg.Sinks,
+		minLevel:            minLevel,
+		sampler:             cfg.Sampler,
+		exitFunc:            cfg.ExitFunc,
+		captureStackAtLevel: captureStackAtLevel,
 	}
 }
 
 // Middleware creates a middleware for use in an HTTP context.
 // Each request will get its own logger.
 func Middleware(cfg Config) func(http.Handler) http.Handler {
+	maxBodyBytes := cfg.MaxBodyBytes
+	if maxBodyBytes <= 0 {
+		maxBodyBytes = defaultMaxBodyBytes
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			logger := NewLogger(cfg).WithRequest(r)
 			defer logger.Flush()
 
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+
+				err, ok := rec.(error)
+				if !ok {
+					err = fmt.Errorf("%v", rec)
+				}
+
+				entry := logger.Log(Fatal, "panic recovered")
+				entry.ErrorMessage = err.Error()
+				entry.Stack = string(debug.Stack())
+
+				w.WriteHeader(http.StatusInternalServerError)
+			}()
+
 			ctx := context.WithValue(r.Context(), contextKeyRequest, logger)
 
 			// wrap the response writer so we can read its values after the request completes
 			wrappedResponseWriter := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
 
-			next.ServeHTTP(wrappedResponseWriter, r.WithContext(ctx))
+			skip := isSkipped(r, cfg)
+
+			var reqBody *boundedBuffer
+			if cfg.AccessLog && !skip && r.Body != nil {
+				// max stays 0 unless the body itself is captured, so only
+				// the byte count for BytesIn is kept.
+				reqBody = &boundedBuffer{}
+				if cfg.CaptureRequestBody {
+					reqBody.max = maxBodyBytes
+				}
+				r.Body = &teeReadCloser{
+					Reader: io.TeeReader(r.Body, reqBody),
+					Closer: r.Body,
+				}
+			}
+
+			respWriter := http.ResponseWriter(wrappedResponseWriter)
+
+			var respBodyBuf *bytes.Buffer
+			if cfg.AccessLog && !skip && cfg.CaptureResponseBody {
+				respBodyBuf = &bytes.Buffer{}
+				respWriter = &bodyCapturingWriter{
+					WrapResponseWriter: wrappedResponseWriter,
+					buf:                respBodyBuf,
+					max:                maxBodyBytes,
+				}
+			}
+
+			next.ServeHTTP(respWriter, r.WithContext(ctx))
+
 			logger.DecorateEntries(func(entry *Entry) *Entry {
 				entry.StatusCode = wrappedResponseWriter.Status()
 				return entry
 			})
+
+			if cfg.AccessLog && !skip {
+				entry := logger.Log(Info, fmt.Sprintf("%s %s", r.Method, r.URL.Path))
+				entry.Type = "http"
+				entry.StatusCode = wrappedResponseWriter.Status()
+				entry.BytesOut = wrappedResponseWriter.BytesWritten()
+				entry.RequestHeaders = redactedHeaders(r.Header, cfg.RedactHeaders)
+				entry.ResponseHeaders = redactedHeaders(wrappedResponseWriter.Header(), cfg.RedactHeaders)
+
+				if reqBody != nil {
+					entry.BytesIn = reqBody.Len()
+					entry.RequestBody = reqBody.String()
+				}
+
+				if respBodyBuf != nil {
+					entry.ResponseBody = truncate(respBodyBuf.String(), maxBodyBytes)
+				}
+			}
 		})
 	}
 }
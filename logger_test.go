@@ -2,14 +2,68 @@ package logger
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/matryer/is"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 )
 
+// parseEntries unmarshals every JSON line written to buf into an Entry.
+func parseEntries(t *testing.T, buf *bytes.Buffer) []Entry {
+	t.Helper()
+
+	entries := []Entry{}
+	for _, line := range bytes.Split(buf.Bytes(), []byte{'\n'}) {
+		if !isJSON(string(line)) {
+			continue
+		}
+
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			t.Fatalf("unmarshal entry: %v", err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// fakeSink records every batch it's sent, for assertions in tests.
+type fakeSink struct {
+	mu      sync.Mutex
+	entries []*Entry
+}
+
+func (f *fakeSink) Send(entries []*Entry) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.entries = append(f.entries, entries...)
+
+	return nil
+}
+
+func (f *fakeSink) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.entries)
+}
+
 func TestLogger_Log(t *testing.T) {
 	t.Parallel()
 	is := is.New(t)
@@ -252,3 +306,751 @@ func TestMiddleware(t *testing.T) {
 	is.Equal("my-new-trace", secondLog.TraceID)
 	is.Equal("my.app/some-other-path", secondLog.RequestURL)
 }
+
+func TestSeverityFor(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	is.Equal(SeverityDebug, severityFor(Debug))
+	is.Equal(SeverityInfo, severityFor(Info))
+	is.Equal(SeverityWarning, severityFor(Warn))
+	is.Equal(SeverityError, severityFor(Error))
+	is.Equal(SeverityCritical, severityFor(Fatal))
+}
+
+func TestLogger_Flush_SetsSeverityOnEntry(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	writer := &bytes.Buffer{}
+	lgr := NewLogger(Config{Writer: writer})
+
+	lgr.Error("boom")
+	lgr.Flush()
+
+	entries := parseEntries(t, writer)
+	is.Equal(SeverityError, entries[0].Severity)
+}
+
+// TestSinks_SurviveLoggerClose asserts that Config.Sinks is a pool shared
+// across every Logger built from the same Config, and that closing one
+// per-request Logger (as Middleware/the gRPC interceptors do via defer)
+// does not tear down the pool for loggers created afterwards.
+func TestSinks_SurviveLoggerClose(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	sink := &fakeSink{}
+	pool := NewSinks(SinkConfig{Sink: sink, BatchSize: 1, MaxDelay: 10 * time.Millisecond})
+	defer pool.Close()
+
+	cfg := Config{Sinks: pool}
+
+	for i := 0; i < 3; i++ {
+		lgr := NewLogger(cfg)
+		lgr.Info("hello")
+		lgr.Close()
+	}
+
+	lgr := NewLogger(cfg)
+	lgr.Info("after closes")
+	lgr.Flush()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) && sink.count() < 4 {
+		time.Sleep(time.Millisecond)
+	}
+
+	is.Equal(4, sink.count())
+}
+
+// blockingSink blocks the first call to Send until release is closed, so
+// a test can deterministically hold asyncSink's single worker goroutine
+// inside Send while it fills the sink's queue.
+type blockingSink struct {
+	release chan struct{}
+	started chan struct{}
+	once    sync.Once
+
+	mu      sync.Mutex
+	batches [][]*Entry
+}
+
+func newBlockingSink() *blockingSink {
+	return &blockingSink{release: make(chan struct{}), started: make(chan struct{})}
+}
+
+func (s *blockingSink) Send(entries []*Entry) error {
+	s.once.Do(func() {
+		close(s.started)
+		<-s.release
+	})
+
+	s.mu.Lock()
+	s.batches = append(s.batches, entries)
+	s.mu.Unlock()
+
+	return nil
+}
+
+// messages returns the Message of every entry sent, across all batches,
+// in delivery order.
+func (s *blockingSink) messages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []string
+	for _, batch := range s.batches {
+		for _, e := range batch {
+			out = append(out, e.Message)
+		}
+	}
+
+	return out
+}
+
+// errSink always fails Send, recording every batch it was given.
+type errSink struct {
+	err error
+
+	mu      sync.Mutex
+	batches [][]*Entry
+}
+
+func (s *errSink) Send(entries []*Entry) error {
+	s.mu.Lock()
+	s.batches = append(s.batches, entries)
+	s.mu.Unlock()
+
+	return s.err
+}
+
+func TestAsyncSink_FlushesOnBatchSize(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	sink := &fakeSink{}
+	a := newAsyncSink(SinkConfig{Sink: sink, BatchSize: 3, MaxDelay: time.Hour})
+	defer a.close()
+
+	a.enqueue(&Entry{Message: "1"})
+	a.enqueue(&Entry{Message: "2"})
+
+	time.Sleep(20 * time.Millisecond)
+	is.Equal(0, sink.count()) // below BatchSize and MaxDelay hasn't elapsed
+
+	a.enqueue(&Entry{Message: "3"})
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) && sink.count() < 3 {
+		time.Sleep(time.Millisecond)
+	}
+
+	is.Equal(3, sink.count())
+}
+
+func TestAsyncSink_FlushesOnMaxDelay(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	sink := &fakeSink{}
+	a := newAsyncSink(SinkConfig{Sink: sink, BatchSize: 10, MaxDelay: 20 * time.Millisecond})
+	defer a.close()
+
+	a.enqueue(&Entry{Message: "1"})
+	a.enqueue(&Entry{Message: "2"})
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) && sink.count() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	is.Equal(2, sink.count())
+}
+
+func TestAsyncSink_OverflowPolicy_DropOldest(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	sink := newBlockingSink()
+	a := newAsyncSink(SinkConfig{
+		Sink:               sink,
+		BatchSize:          1,
+		MaxDelay:           time.Hour,
+		MaxBufferedEntries: 2,
+		OverflowPolicy:     DropOldest,
+	})
+
+	a.enqueue(&Entry{Message: "a"})
+	<-sink.started // worker is now blocked inside Send("a"); queue is empty
+
+	a.enqueue(&Entry{Message: "b"})
+	a.enqueue(&Entry{Message: "c"}) // queue full: [b, c]
+	a.enqueue(&Entry{Message: "d"}) // drops oldest (b): queue becomes [c, d]
+
+	close(sink.release)
+	a.close()
+
+	is.Equal([]string{"a", "c", "d"}, sink.messages())
+}
+
+func TestAsyncSink_OverflowPolicy_DropNew(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	sink := newBlockingSink()
+	a := newAsyncSink(SinkConfig{
+		Sink:               sink,
+		BatchSize:          1,
+		MaxDelay:           time.Hour,
+		MaxBufferedEntries: 2,
+		OverflowPolicy:     DropNew,
+	})
+
+	a.enqueue(&Entry{Message: "a"})
+	<-sink.started
+
+	a.enqueue(&Entry{Message: "b"})
+	a.enqueue(&Entry{Message: "c"}) // queue full: [b, c]
+	a.enqueue(&Entry{Message: "d"}) // dropped: queue stays [b, c]
+
+	close(sink.release)
+	a.close()
+
+	is.Equal([]string{"a", "b", "c"}, sink.messages())
+}
+
+func TestAsyncSink_OverflowPolicy_Block(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	sink := newBlockingSink()
+	a := newAsyncSink(SinkConfig{
+		Sink:               sink,
+		BatchSize:          1,
+		MaxDelay:           time.Hour,
+		MaxBufferedEntries: 2,
+		OverflowPolicy:     Block,
+	})
+
+	a.enqueue(&Entry{Message: "a"})
+	<-sink.started
+
+	a.enqueue(&Entry{Message: "b"})
+	a.enqueue(&Entry{Message: "c"}) // queue full: [b, c]
+
+	enqueued := make(chan struct{})
+	go func() {
+		a.enqueue(&Entry{Message: "d"}) // must block until the queue has room
+		close(enqueued)
+	}()
+
+	select {
+	case <-enqueued:
+		t.Fatal("enqueue with Block policy returned before the queue had room")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(sink.release) // worker drains a, then b, then c -- freeing room for d
+
+	select {
+	case <-enqueued:
+	case <-time.After(time.Second):
+		t.Fatal("blocked enqueue never completed once the queue drained")
+	}
+
+	a.close()
+
+	is.Equal([]string{"a", "b", "c", "d"}, sink.messages())
+}
+
+func TestAsyncSink_OnError(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	sendErr := errors.New("boom")
+	sink := &errSink{err: sendErr}
+
+	var mu sync.Mutex
+	var gotErr error
+	var gotBatch []*Entry
+
+	a := newAsyncSink(SinkConfig{
+		Sink:      sink,
+		BatchSize: 1,
+		MaxDelay:  time.Hour,
+		OnError: func(err error, entries []*Entry) {
+			mu.Lock()
+			defer mu.Unlock()
+			gotErr = err
+			gotBatch = entries
+		},
+	})
+	defer a.close()
+
+	entry := &Entry{Message: "boom-entry"}
+	a.enqueue(entry)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		done := gotErr != nil
+		mu.Unlock()
+
+		if done || time.Now().After(deadline) {
+			break
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	is.Equal(sendErr, gotErr)
+	is.Equal(1, len(gotBatch))
+	is.Equal(entry, gotBatch[0])
+}
+
+func TestLogger_With_InheritsFields(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	writer := &bytes.Buffer{}
+	lgr := NewLogger(Config{Writer: writer})
+
+	child := lgr.With("user_id", "123", "trace_id", "abc-trace")
+	child.Info("hi")
+	child.Flush()
+
+	entries := parseEntries(t, writer)
+	is.Equal("123", entries[0].Fields["user_id"])
+	is.Equal("abc-trace", entries[0].TraceID) // well-known key flattens to the top-level field
+
+	writer.Reset()
+
+	grandchild := child.With("extra", "val")
+	grandchild.Info("hi again")
+	grandchild.Flush()
+
+	entries = parseEntries(t, writer)
+	is.Equal("123", entries[0].Fields["user_id"]) // inherited from parent
+	is.Equal("val", entries[0].Fields["extra"])
+	is.Equal("abc-trace", entries[0].TraceID)
+}
+
+func TestLogger_With_DoesNotMutateParent(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	writer := &bytes.Buffer{}
+	lgr := NewLogger(Config{Writer: writer})
+
+	_ = lgr.With("user_id", "123")
+	lgr.Info("parent entry")
+	lgr.Flush()
+
+	entries := parseEntries(t, writer)
+	is.Equal(nil, entries[0].Fields)
+}
+
+// TestMiddleware_WithChildLogger exercises the shared buffer described in
+// the With doc comment: a handler binds a field via FromContext+With and
+// logs on the child, relying on Middleware's deferred Flush (on the
+// *original* logger) to actually write that entry out.
+func TestMiddleware_WithChildLogger(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	writer := &bytes.Buffer{}
+	cfg := Config{Writer: writer}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lgr, err := FromContext(r.Context())
+		is.NoErr(err)
+
+		child := lgr.With("user_id", "123")
+		child.Info("bound via child")
+	})
+
+	resp := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "https://my.app/some-path", nil)
+
+	Middleware(cfg)(handler).ServeHTTP(resp, req)
+
+	entries := parseEntries(t, writer)
+	is.Equal(1, len(entries))
+	is.Equal("bound via child", entries[0].Message)
+	is.Equal("123", entries[0].Fields["user_id"])
+}
+
+func TestEntry_WithFields_WellKnownFlattening(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	e := &Entry{}
+	e.WithFields(map[string]any{"causer_id": "42", "foo": "bar"})
+
+	is.Equal("42", e.CauserID)
+	is.Equal("bar", e.Fields["foo"])
+	is.Equal(nil, e.Fields["causer_id"])
+}
+
+func TestMiddleware_AccessLog(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	writer := &bytes.Buffer{}
+	cfg := Config{
+		Writer:              writer,
+		AccessLog:           true,
+		CaptureRequestBody:  true,
+		CaptureResponseBody: true,
+		RedactHeaders:       []string{"Authorization"},
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("resp-body"))
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "https://my.app/things?x=1", strings.NewReader("req-body"))
+	req.Header.Set("Authorization", "secret-token")
+	resp := httptest.NewRecorder()
+
+	Middleware(cfg)(handler).ServeHTTP(resp, req)
+
+	entries := parseEntries(t, writer)
+
+	var accessEntry *Entry
+	for i := range entries {
+		if entries[i].Type == "http" {
+			accessEntry = &entries[i]
+		}
+	}
+
+	if accessEntry == nil {
+		t.Fatal("expected an access-log entry of type \"http\"")
+	}
+
+	is.Equal(http.StatusCreated, accessEntry.StatusCode)
+	is.Equal("req-body", accessEntry.RequestBody)
+	is.Equal("resp-body", accessEntry.ResponseBody)
+	is.Equal("***", accessEntry.RequestHeaders["Authorization"])
+}
+
+func TestMiddleware_SkipPaths(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	writer := &bytes.Buffer{}
+	cfg := Config{
+		Writer:    writer,
+		AccessLog: true,
+		SkipPaths: []string{"/healthz"},
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "https://my.app/healthz", nil)
+	resp := httptest.NewRecorder()
+
+	Middleware(cfg)(handler).ServeHTTP(resp, req)
+
+	entries := parseEntries(t, writer)
+	for _, e := range entries {
+		is.True(e.Type != "http")
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	writer := &bytes.Buffer{}
+	interceptor := UnaryServerInterceptor(Config{Writer: writer})
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/svc.Thing/Do"}
+
+	handler := func(ctx context.Context, req any) (any, error) {
+		lgr, err := FromContext(ctx)
+		is.NoErr(err)
+		lgr.Info("inside handler")
+
+		return "ok", status.Error(codes.NotFound, "missing")
+	}
+
+	_, err := interceptor(context.Background(), "req", info, handler)
+	if err == nil {
+		t.Fatal("expected an error from the handler")
+	}
+
+	entries := parseEntries(t, writer)
+
+	last := entries[len(entries)-1]
+	is.Equal("grpc", last.ProcessContext)
+	is.Equal(info.FullMethod, last.GRPCMethod)
+	is.Equal(codes.NotFound.String(), last.GRPCCode)
+
+	for _, e := range entries {
+		is.Equal(info.FullMethod, e.GRPCMethod)
+		is.Equal(codes.NotFound.String(), e.GRPCCode)
+	}
+}
+
+// fakeServerStream is a grpc.ServerStream whose Context() can be
+// overridden; no other method is exercised by StreamServerInterceptor or
+// its handler in these tests.
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (f *fakeServerStream) Context() context.Context {
+	return f.ctx
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	writer := &bytes.Buffer{}
+	interceptor := StreamServerInterceptor(Config{Writer: writer})
+
+	info := &grpc.StreamServerInfo{FullMethod: "/svc.Thing/Stream"}
+
+	addr := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+	ss := &fakeServerStream{ctx: ctx}
+
+	handler := func(srv any, stream grpc.ServerStream) error {
+		lgr, err := FromContext(stream.Context())
+		is.NoErr(err)
+		lgr.Info("inside handler")
+
+		return status.Error(codes.NotFound, "missing")
+	}
+
+	err := interceptor(nil, ss, info, handler)
+	if err == nil {
+		t.Fatal("expected an error from the handler")
+	}
+
+	entries := parseEntries(t, writer)
+
+	last := entries[len(entries)-1]
+	is.Equal("grpc", last.ProcessContext)
+	is.Equal(info.FullMethod, last.GRPCMethod)
+	is.Equal(codes.NotFound.String(), last.GRPCCode)
+	is.Equal(addr.String(), last.RemoteAddress)
+
+	for _, e := range entries {
+		is.Equal(info.FullMethod, e.GRPCMethod)
+		is.Equal(codes.NotFound.String(), e.GRPCCode)
+	}
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	writer := &bytes.Buffer{}
+	interceptor := UnaryClientInterceptor(Config{Writer: writer})
+
+	method := "/svc.Thing/Do"
+
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return status.Error(codes.Unavailable, "down")
+	}
+
+	err := interceptor(context.Background(), method, "req", "reply", nil, invoker)
+	if err == nil {
+		t.Fatal("expected an error from the invoker")
+	}
+
+	entries := parseEntries(t, writer)
+	last := entries[len(entries)-1]
+	is.Equal("grpc", last.ProcessContext)
+	is.Equal(method, last.GRPCMethod)
+	is.Equal(codes.Unavailable.String(), last.GRPCCode)
+}
+
+func TestStreamClientInterceptor(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	writer := &bytes.Buffer{}
+	interceptor := StreamClientInterceptor(Config{Writer: writer})
+
+	method := "/svc.Thing/Stream"
+	desc := &grpc.StreamDesc{StreamName: "Stream"}
+
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return nil, status.Error(codes.Canceled, "canceled")
+	}
+
+	_, err := interceptor(context.Background(), desc, nil, method, streamer)
+	if err == nil {
+		t.Fatal("expected an error from the streamer")
+	}
+
+	entries := parseEntries(t, writer)
+	last := entries[len(entries)-1]
+	is.Equal("grpc", last.ProcessContext)
+	is.Equal(method, last.GRPCMethod)
+	is.Equal(codes.Canceled.String(), last.GRPCCode)
+}
+
+func TestLogger_MinLevel_DropsBelowThreshold(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	writer := &bytes.Buffer{}
+	lgr := NewLogger(Config{Writer: writer, MinLevel: Warn})
+
+	lgr.Debug("dropped")
+	lgr.Info("dropped too")
+	lgr.Warn("kept")
+	lgr.Flush()
+
+	entries := parseEntries(t, writer)
+	is.Equal(1, len(entries))
+	is.Equal("kept", entries[0].Message)
+}
+
+func TestLogger_MinLevel_EnvOverride(t *testing.T) {
+	is := is.New(t)
+	t.Setenv("LOG_LEVEL", "error")
+
+	writer := &bytes.Buffer{}
+	lgr := NewLogger(Config{Writer: writer, MinLevel: Debug})
+
+	lgr.Warn("dropped by env override")
+	lgr.Error("kept")
+	lgr.Flush()
+
+	entries := parseEntries(t, writer)
+	is.Equal(1, len(entries))
+	is.Equal("kept", entries[0].Message)
+}
+
+func TestNewRateSampler(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	sampler := NewRateSampler(2)
+
+	kept := 0
+	for i := 0; i < 10; i++ {
+		if sampler(&Entry{Level: Info.String()}) {
+			kept++
+		}
+	}
+
+	is.Equal(5, kept)
+	is.True(sampler(&Entry{Level: Warn.String()}))
+	is.True(sampler(&Entry{Level: Error.String()}))
+	is.True(sampler(&Entry{Level: Fatal.String()}))
+}
+
+func TestLogger_Fatal_CallsExitFuncAndFlushes(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	writer := &bytes.Buffer{}
+
+	var exitCode int
+	exited := false
+
+	lgr := NewLogger(Config{
+		Writer: writer,
+		ExitFunc: func(code int) {
+			exited = true
+			exitCode = code
+		},
+	})
+
+	lgr.Fatal("boom")
+
+	is.True(exited)
+	is.Equal(1, exitCode)
+
+	entries := parseEntries(t, writer)
+	is.Equal(1, len(entries))
+	is.Equal(Fatal.String(), entries[0].Level)
+	is.Equal("boom", entries[0].Message)
+}
+
+func TestLogger_Error_CapturesStack(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	writer := &bytes.Buffer{}
+	lgr := NewLogger(Config{Writer: writer})
+
+	errEntry := lgr.Error("boom")
+	is.True(errEntry.Stack != "")
+
+	debugEntry := lgr.Debug("fine")
+	is.Equal("", debugEntry.Stack)
+}
+
+func TestConfig_CaptureStackAtLevel_ExplicitDebug(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	writer := &bytes.Buffer{}
+	lgr := NewLogger(Config{Writer: writer, CaptureStackAtLevel: LevelPtr(Debug)})
+
+	entry := lgr.Debug("trace me")
+	is.True(entry.Stack != "")
+}
+
+func TestEntry_WithError_SetsMessageAndStack(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	e := &Entry{}
+	e.WithError(errors.New("oops"))
+
+	is.Equal("oops", e.ErrorMessage)
+	is.True(e.Stack != "")
+}
+
+func TestMiddleware_RecoversPanic(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	writer := &bytes.Buffer{}
+	cfg := Config{Writer: writer}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("kaboom")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "https://my.app/x", nil)
+	resp := httptest.NewRecorder()
+
+	Middleware(cfg)(handler).ServeHTTP(resp, req)
+
+	is.Equal(http.StatusInternalServerError, resp.Code)
+
+	entries := parseEntries(t, writer)
+
+	var fatalEntry *Entry
+	for i := range entries {
+		if entries[i].Level == Fatal.String() {
+			fatalEntry = &entries[i]
+		}
+	}
+
+	if fatalEntry == nil {
+		t.Fatal("expected a fatal-level entry recording the panic")
+	}
+
+	is.Equal("kaboom", fatalEntry.ErrorMessage)
+	is.True(fatalEntry.Stack != "")
+}
@@ -0,0 +1,23 @@
+package logger
+
+import "sync/atomic"
+
+// NewRateSampler returns a Config.Sampler that keeps 1 of every n Info/Debug
+// entries but always keeps Warn/Error/Fatal entries. Useful for high-QPS
+// services where buffering every entry is a memory-growth hazard.
+func NewRateSampler(n int) func(*Entry) bool {
+	if n <= 0 {
+		n = 1
+	}
+
+	var count uint64
+
+	return func(e *Entry) bool {
+		switch e.Level {
+		case Warn.String(), Error.String(), Fatal.String():
+			return true
+		}
+
+		return atomic.AddUint64(&count, 1)%uint64(n) == 0
+	}
+}
@@ -0,0 +1,293 @@
+package logger
+
+import (
+	"sync"
+	"time"
+)
+
+// Severity is the severity level expected by remote log sinks such as
+// Google Cloud Logging or an OTLP collector. It is derived from a level
+// via severityFor.
+type Severity string
+
+const (
+	SeverityDebug    Severity = "DEBUG"
+	SeverityInfo     Severity = "INFO"
+	SeverityWarning  Severity = "WARNING"
+	SeverityError    Severity = "ERROR"
+	SeverityCritical Severity = "CRITICAL"
+)
+
+// severityFor maps one of our levels to the Severity expected by remote
+// sinks. Levels we don't recognize map to SeverityDebug.
+func severityFor(lvl level) Severity {
+	switch lvl {
+	case Debug:
+		return SeverityDebug
+	case Info:
+		return SeverityInfo
+	case Warn:
+		return SeverityWarning
+	case Error:
+		return SeverityError
+	case Fatal:
+		return SeverityCritical
+	}
+
+	return SeverityDebug
+}
+
+// OverflowPolicy decides what happens when a sink's in-memory queue is
+// full and a new entry needs to be enqueued.
+type OverflowPolicy int
+
+const (
+	// DropOldest discards the oldest queued entry to make room for the new one.
+	DropOldest OverflowPolicy = iota
+	// DropNew discards the entry that was about to be enqueued.
+	DropNew
+	// Block waits until space is available in the queue.
+	Block
+)
+
+// Sink delivers a batch of entries to a remote destination, e.g. Google
+// Cloud Logging, Datadog, or an OTLP endpoint. Implementations should
+// treat entries as read-only.
+type Sink interface {
+	Send(entries []*Entry) error
+}
+
+// SinkConfig configures how a Sink is fed: how entries are batched,
+// how much is buffered in memory, and what happens on overflow or
+// delivery failure.
+type SinkConfig struct {
+	Sink Sink
+
+	// BatchSize is the number of entries sent to Sink.Send at a time.
+	// Defaults to 1 if unset.
+	BatchSize int
+
+	// MaxDelay is the longest we'll hold a partial batch before sending
+	// it anyway. Defaults to 1s if unset.
+	MaxDelay time.Duration
+
+	// MaxBufferedEntries bounds the in-memory queue. Defaults to 1000 if unset.
+	MaxBufferedEntries int
+
+	// OverflowPolicy decides what happens once the queue is full.
+	OverflowPolicy OverflowPolicy
+
+	// OnError is called with any error returned by Sink.Send and the
+	// batch of entries that failed to send. If nil, delivery failures
+	// are silently dropped.
+	OnError func(error, []*Entry)
+}
+
+// asyncSink buffers entries in memory and delivers them to a Sink in
+// batches on a background goroutine.
+type asyncSink struct {
+	cfg   SinkConfig
+	queue chan *Entry
+	flush chan chan struct{}
+	done  chan struct{}
+	wg    sync.WaitGroup
+}
+
+func newAsyncSink(cfg SinkConfig) *asyncSink {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 1
+	}
+
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = time.Second
+	}
+
+	if cfg.MaxBufferedEntries <= 0 {
+		cfg.MaxBufferedEntries = 1000
+	}
+
+	a := &asyncSink{
+		cfg:   cfg,
+		queue: make(chan *Entry, cfg.MaxBufferedEntries),
+		flush: make(chan chan struct{}),
+		done:  make(chan struct{}),
+	}
+
+	a.wg.Add(1)
+	go a.run()
+
+	return a
+}
+
+// enqueue adds an entry to the queue, applying the configured
+// OverflowPolicy if the queue is full.
+func (a *asyncSink) enqueue(e *Entry) {
+	select {
+	case a.queue <- e:
+		return
+	default:
+	}
+
+	switch a.cfg.OverflowPolicy {
+	case Block:
+		a.queue <- e
+	case DropNew:
+		return
+	case DropOldest:
+		select {
+		case <-a.queue:
+		default:
+		}
+
+		select {
+		case a.queue <- e:
+		default:
+		}
+	}
+}
+
+// run batches entries off the queue and sends them to the sink, either
+// once BatchSize entries have accumulated or MaxDelay has elapsed.
+func (a *asyncSink) run() {
+	defer a.wg.Done()
+
+	batch := make([]*Entry, 0, a.cfg.BatchSize)
+	timer := time.NewTimer(a.cfg.MaxDelay)
+	defer timer.Stop()
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+
+		if err := a.cfg.Sink.Send(batch); err != nil && a.cfg.OnError != nil {
+			a.cfg.OnError(err, batch)
+		}
+
+		batch = make([]*Entry, 0, a.cfg.BatchSize)
+	}
+
+	// drainQueued moves whatever is already sitting in the queue into
+	// batch without blocking, for use before a synchronous or shutdown
+	// flush.
+	drainQueued := func() {
+		for {
+			select {
+			case e := <-a.queue:
+				batch = append(batch, e)
+			default:
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case e, ok := <-a.queue:
+			if !ok {
+				flush()
+				return
+			}
+
+			batch = append(batch, e)
+			if len(batch) >= a.cfg.BatchSize {
+				flush()
+				timer.Reset(a.cfg.MaxDelay)
+			}
+		case <-timer.C:
+			flush()
+			timer.Reset(a.cfg.MaxDelay)
+		case ack := <-a.flush:
+			drainQueued()
+			flush()
+			close(ack)
+		case <-a.done:
+			// drain whatever is already queued before shutting down
+			drainQueued()
+			flush()
+			return
+		}
+	}
+}
+
+// flushSync blocks until every entry currently queued has been handed to
+// Sink.Send, for callers (Logger.FlushSync) that need delivery to have
+// actually happened before returning -- e.g. immediately before os.Exit.
+// It is a no-op if the sink has already been closed.
+func (a *asyncSink) flushSync() {
+	ack := make(chan struct{})
+
+	select {
+	case a.flush <- ack:
+		<-ack
+	case <-a.done:
+	}
+}
+
+// close drains the queue and stops the background goroutine.
+func (a *asyncSink) close() {
+	close(a.done)
+	a.wg.Wait()
+}
+
+// Sinks is a pool of sinks built once, at application startup, and shared
+// by every Logger created afterwards (e.g. one Logger per request/RPC).
+// Build it with NewSinks, assign it to Config.Sinks, and call Close on it
+// yourself during application shutdown -- a per-request Logger does not
+// own the pool and will not shut it down.
+type Sinks struct {
+	sinks []*asyncSink
+}
+
+// NewSinks builds a Sinks pool, starting one background worker goroutine
+// per SinkConfig.
+func NewSinks(cfgs ...SinkConfig) *Sinks {
+	sinks := make([]*asyncSink, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		sinks = append(sinks, newAsyncSink(cfg))
+	}
+
+	return &Sinks{sinks: sinks}
+}
+
+// enqueue hands entries to every sink in the pool for asynchronous delivery.
+func (s *Sinks) enqueue(entries []*Entry) {
+	if s == nil {
+		return
+	}
+
+	for _, sink := range s.sinks {
+		for _, e := range entries {
+			sink.enqueue(e)
+		}
+	}
+}
+
+// enqueueSync hands entries to every sink in the pool and blocks until
+// each sink has actually sent them, instead of only queuing them for the
+// background worker. Used by Logger.FlushSync.
+func (s *Sinks) enqueueSync(entries []*Entry) {
+	if s == nil {
+		return
+	}
+
+	for _, sink := range s.sinks {
+		for _, e := range entries {
+			sink.enqueue(e)
+		}
+
+		sink.flushSync()
+	}
+}
+
+// Close drains every sink's queue and stops its worker goroutine. Call
+// this once, during application shutdown -- not from a per-request Logger.
+func (s *Sinks) Close() {
+	if s == nil {
+		return
+	}
+
+	for _, sink := range s.sinks {
+		sink.close()
+	}
+}
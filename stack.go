@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const maxStackFrames = 32
+
+// captureStack renders the current goroutine's call stack, skipping
+// frames inside this package itself so the top frame is the caller.
+func captureStack() string {
+	pcs := make([]uintptr, maxStackFrames)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var sb strings.Builder
+
+	for {
+		frame, more := frames.Next()
+
+		if !isInternalFrame(frame.File) {
+			fmt.Fprintf(&sb, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		}
+
+		if !more {
+			break
+		}
+	}
+
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// isInternalFrame reports whether file's base name is one of this
+// package's non-test source files, so its frames can be filtered out of a
+// captured stack. Only the base name is compared, so a same-named file in
+// another package is filtered too.
+func isInternalFrame(file string) bool {
+	switch filepath.Base(file) {
+	case "access_log.go", "entry.go", "grpc.go", "logger.go", "sampling.go", "sink.go", "stack.go":
+		return true
+	}
+
+	return false
+}